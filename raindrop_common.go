@@ -15,6 +15,7 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -290,7 +291,7 @@ func get_collections(token RaindropToken, sublevel bool, caching string) []inter
 	json.Unmarshal(response_body, &cache_base)
 
 	// Write to file
-	os.WriteFile(cache_filename, response_body, 0666)
+	write_cache_file(cache_filename, response_body)
 
 	// Return collections
 	if cache_base["items"] != nil && cache_base["items"].([]interface{}) != nil {
@@ -299,6 +300,49 @@ func get_collections(token RaindropToken, sublevel bool, caching string) []inter
 	return collections
 }
 
+// Function for writing a cache file atomically.
+// The content is written to a temporary file in the same folder, which is then renamed to the final name.
+// That way, a search running in parallel (which Alfred may start while the user is still typing) never reads a half written cache file.
+// If the process is killed before the rename, the temporary file is left behind, and is removed later by remove_stale_cache_files.
+func write_cache_file(filename string, data []byte) error {
+	temp_file, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	temp_filename := temp_file.Name()
+	// os.CreateTemp creates the file with mode 0600, so set the same permissions as os.WriteFile(..., 0666) gives with the default umask
+	if err := temp_file.Chmod(0644); err != nil {
+		temp_file.Close()
+		os.Remove(temp_filename)
+		return err
+	}
+	if _, err := temp_file.Write(data); err != nil {
+		temp_file.Close()
+		os.Remove(temp_filename)
+		return err
+	}
+	if err := temp_file.Close(); err != nil {
+		os.Remove(temp_filename)
+		return err
+	}
+	if err := os.Rename(temp_filename, filename); err != nil {
+		os.Remove(temp_filename)
+		return err
+	}
+	return nil
+}
+
+// Function for removing temporary cache files left behind by write_cache_file when a process was killed before finishing the write
+func remove_stale_cache_files(cache_dir string) {
+	temp_filenames, _ := filepath.Glob(filepath.Join(cache_dir, "*.json.*.tmp"))
+	for _, temp_filename := range temp_filenames {
+		// Only remove files that are a few minutes old, so that writes that are still in progress in another process are left alone
+		if file_stat, err := os.Stat(temp_filename); err == nil && time.Since(file_stat.ModTime()).Minutes() > 5 {
+			os.Remove(temp_filename)
+		}
+	}
+}
+
 // Returns only the hostname minus www from a given URL
 func get_hostname(url_string string) string {
 	url_object, _ := url.Parse(url_string)
@@ -532,7 +576,7 @@ func get_tags(token RaindropToken, caching string) []interface{} {
 	json.Unmarshal(response_body, &cache_base)
 
 	// Write to file
-	os.WriteFile(wf.CacheDir()+"/tags.json", response_body, 0666)
+	write_cache_file(wf.CacheDir()+"/tags.json", response_body)
 
 	// Return tags
 	if cache_base["items"] != nil && cache_base["items"].([]interface{}) != nil {
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Check that concurrent writers and readers of the same cache file never see a half written file
+func TestWriteCacheFileInterleaved(t *testing.T) {
+	cache_dir := t.TempDir()
+	cache_filename := filepath.Join(cache_dir, "bookmarks.json")
+
+	// Each writer gets a distinct, large payload, so that a mixed or partially written file can be detected
+	writer_count := 8
+	payloads := make([][]byte, writer_count)
+	for i := range payloads {
+		payloads[i] = bytes.Repeat([]byte{byte('a' + i)}, 1<<20)
+	}
+
+	// Write an initial version, so that readers always have a file to read
+	if err := write_cache_file(cache_filename, payloads[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	var writers sync.WaitGroup
+	var readers sync.WaitGroup
+	done := make(chan struct{})
+	errs := make(chan error, writer_count*10)
+
+	for i := 0; i < writer_count; i++ {
+		writers.Add(1)
+		go func(payload []byte) {
+			defer writers.Done()
+			for j := 0; j < 10; j++ {
+				if err := write_cache_file(cache_filename, payload); err != nil {
+					errs <- err
+				}
+			}
+		}(payloads[i])
+	}
+
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				content, err := os.ReadFile(cache_filename)
+				if err != nil {
+					t.Errorf("failed to read cache file: %v", err)
+					return
+				}
+				matched := false
+				for _, payload := range payloads {
+					if bytes.Equal(content, payload) {
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					t.Errorf("read a cache file of %d bytes that does not match any complete payload", len(content))
+					return
+				}
+			}
+		}()
+	}
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("failed to write cache file: %v", err)
+	}
+
+	// No temporary files should be left behind
+	temp_filenames, _ := filepath.Glob(filepath.Join(cache_dir, "*.tmp"))
+	if len(temp_filenames) > 0 {
+		t.Errorf("temporary files left in cache folder: %v", temp_filenames)
+	}
+
+	// The cache file should keep the same permissions as os.WriteFile(..., 0666) gives with the default umask
+	file_stat, err := os.Stat(cache_filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file_stat.Mode().Perm() != 0644 {
+		t.Errorf("cache file has mode %v, expected 0644", file_stat.Mode().Perm())
+	}
+}
@@ -31,6 +31,9 @@ func get_all_bookmarks(token RaindropToken, caching string) []interface{} {
 	var cache_base map[string]interface{}
 	var cache_filename string = wf.CacheDir() + "/bookmarks.json"
 
+	// Clean up temporary cache files from writes that never finished
+	remove_stale_cache_files(wf.CacheDir())
+
 	// Check if cache files exist
 	var bookmarks_cache_exists bool = false
 	var collections_cache_exists bool = false
@@ -138,7 +141,7 @@ func get_all_bookmarks(token RaindropToken, caching string) []interface{} {
 
 	// Write to cache file
 	result_json, _ := json.Marshal(result)
-	os.WriteFile(cache_filename, result_json, 0666)
+	write_cache_file(cache_filename, result_json)
 
 	// If we've updated the bookmarks cache, also update tags and collections
 	if caching == "fetch" {